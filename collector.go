@@ -1,12 +1,13 @@
 package main
 
 import (
+	"log/slog"
 	"strings"
 	"sync"
+	"time"
 
 	mon "github.com/digineo/go-ping/monitor"
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/common/log"
 )
 
 func newDesc(name, help string, variableLabels []string, constLabels prometheus.Labels) *prometheus.Desc {
@@ -14,23 +15,37 @@ func newDesc(name, help string, variableLabels []string, constLabels prometheus.
 }
 
 var (
-	labelNames = []string{"target", "ip", "ip_version"}
-	rttDesc    = newScaledDesc("rtt_seconds", "Round trip time", append(labelNames, "type"))
-	bestDesc   = newScaledDesc("rtt_best_seconds", "Best round trip time", labelNames)
-	worstDesc  = newScaledDesc("rtt_worst_seconds", "Worst round trip time", labelNames)
-	meanDesc   = newScaledDesc("rtt_mean_seconds", "Mean round trip time", labelNames)
-	stddevDesc = newScaledDesc("rtt_std_deviation_seconds", "Standard deviation", labelNames)
-	lossDesc   = newDesc("loss_percent", "Packet loss in percent", labelNames, nil)
-	progDesc   = newDesc("up", "ping_exporter version", nil, prometheus.Labels{"version": version})
-	mutex      = &sync.Mutex{}
+	labelNames         = []string{"target", "ip", "ip_version"}
+	rttDesc            = newScaledDesc("rtt_seconds", "Round trip time", append(labelNames, "type"))
+	bestDesc           = newScaledDesc("rtt_best_seconds", "Best round trip time", labelNames)
+	worstDesc          = newScaledDesc("rtt_worst_seconds", "Worst round trip time", labelNames)
+	meanDesc           = newScaledDesc("rtt_mean_seconds", "Mean round trip time", labelNames)
+	stddevDesc         = newScaledDesc("rtt_std_deviation_seconds", "Standard deviation", labelNames)
+	lossDesc           = newDesc("loss_percent", "Packet loss in percent", labelNames, nil)
+	progDesc           = newDesc("up", "ping_exporter version", nil, prometheus.Labels{"version": version})
+	scrapeDurationDesc = newDesc("scrape_collector_duration_seconds", "Time it took to collect metrics for a target", []string{"target"}, nil)
+	scrapeSuccessDesc  = newDesc("scrape_collector_success", "Whether a target has at least one usable ping sample, mirroring the PacketsSent > PacketsLost gate emitMetrics uses for its RTT series", []string{"target"}, nil)
+	mutex              = &sync.Mutex{}
+
+	configReloadsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ping_exporter_config_reloads_total",
+		Help: "Number of config reloads by result",
+	}, []string{"result"})
 )
 
-type pingBatchCollector struct {
-	monitor *mon.Monitor
+// deviceCollector collects ping metrics for a set of targets, one goroutine
+// per target, so a slow or unresponsive target cannot hold up the rest of
+// the scrape. A nil targets field means "all targets currently monitored",
+// which is what the aggregate /metrics endpoint uses; the ?target= probe
+// handler in startServer instead scopes it to a single target.
+type deviceCollector struct {
+	logger  *slog.Logger
+	targets []string
+
 	metrics map[string]*mon.Metrics
 }
 
-func (p *pingBatchCollector) Describe(ch chan<- *prometheus.Desc) {
+func (p *deviceCollector) Describe(ch chan<- *prometheus.Desc) {
 	if enableDeprecatedMetrics {
 		rttDesc.Describe(ch)
 	}
@@ -40,69 +55,79 @@ func (p *pingBatchCollector) Describe(ch chan<- *prometheus.Desc) {
 	stddevDesc.Describe(ch)
 	ch <- lossDesc
 	ch <- progDesc
+	ch <- scrapeDurationDesc
+	ch <- scrapeSuccessDesc
+	configReloadsTotal.Describe(ch)
 }
 
-func (p *pingBatchCollector) Collect(ch chan<- prometheus.Metric) {
+func (p *deviceCollector) Collect(ch chan<- prometheus.Metric) {
 	mutex.Lock()
-	defer mutex.Unlock()
-
-	if m := p.monitor.Export(); len(m) > 0 {
+	if m := activeMonitor.Load().Export(); len(m) > 0 {
 		p.metrics = m
 	}
+	snapshot := p.metrics
+	mutex.Unlock()
 
 	ch <- prometheus.MustNewConstMetric(progDesc, prometheus.GaugeValue, 1)
+	configReloadsTotal.Collect(ch)
 
-	for target, metrics := range p.metrics {
-		l := strings.SplitN(target, " ", 3)
-
-		if metrics.PacketsSent > metrics.PacketsLost {
-			if enableDeprecatedMetrics {
-				rttDesc.Collect(ch, metrics.Best, append(l, "best")...)
-				rttDesc.Collect(ch, metrics.Worst, append(l, "worst")...)
-				rttDesc.Collect(ch, metrics.Mean, append(l, "mean")...)
-				rttDesc.Collect(ch, metrics.StdDev, append(l, "std_dev")...)
-			}
-
-			bestDesc.Collect(ch, metrics.Best, l...)
-			worstDesc.Collect(ch, metrics.Worst, l...)
-			meanDesc.Collect(ch, metrics.Mean, l...)
-			stddevDesc.Collect(ch, metrics.StdDev, l...)
+	keys := p.targets
+	if keys == nil {
+		keys = make([]string, 0, len(snapshot))
+		for k := range snapshot {
+			keys = append(keys, k)
 		}
-
-		loss := float64(metrics.PacketsLost) / float64(metrics.PacketsSent)
-		ch <- prometheus.MustNewConstMetric(lossDesc, prometheus.GaugeValue, loss, l...)
 	}
-}
 
-type pingCollector struct {
-	target string
-	monitor *mon.Monitor
-}
+	var wg sync.WaitGroup
+	for _, key := range keys {
+		metrics, ok := snapshot[key]
+		if !ok {
+			p.logger.Error("no metrics found", "target", key)
+			continue
+		}
 
-func (p *pingCollector) Describe(ch chan<- *prometheus.Desc) {
-	if enableDeprecatedMetrics {
-		rttDesc.Describe(ch)
+		wg.Add(1)
+		go func(key string, metrics *mon.Metrics) {
+			defer wg.Done()
+			collectTarget(ch, key, metrics)
+		}(key, metrics)
 	}
-	bestDesc.Describe(ch)
-	worstDesc.Describe(ch)
-	meanDesc.Describe(ch)
-	stddevDesc.Describe(ch)
-	ch <- lossDesc
-	ch <- progDesc
+	wg.Wait()
 }
 
-func (p *pingCollector) Collect(ch chan<- prometheus.Metric) {
-	mutex.Lock()
-	defer mutex.Unlock()
-
-	metrics, ok := p.monitor.Export()[p.target]
-	if !ok {
-		log.Errorf("no metrics found for %s", p.target)
-		return
+// collectTarget emits the ping metrics for a single target and records how
+// long formatting them took, plus whether the target currently has a
+// usable sample, via scrapeDurationDesc/scrapeSuccessDesc.
+//
+// emitMetrics only formats a snapshot mon.Monitor already captured in the
+// background; unlike the tcp_connect/http_get probers in probers/, there is
+// no per-scrape I/O here for scrape.timeout to bound, so collectTarget
+// doesn't use it - scrape.timeout is only used by probeModule (modules.go),
+// as the timeout of a probe whose module sets none of its own. deviceCollector.Collect runs one
+// collectTarget per target in its own goroutine, but all of them write
+// into the same ch: that's fine, Prometheus collectors are required to
+// support concurrent sends on the channel they're given.
+func collectTarget(ch chan<- prometheus.Metric, targetKey string, metrics *mon.Metrics) {
+	name := strings.SplitN(targetKey, " ", 2)[0]
+	start := time.Now()
+
+	emitMetrics(ch, targetKey, metrics)
+
+	success := 0.0
+	if metrics.PacketsSent > metrics.PacketsLost {
+		success = 1
 	}
-	ch <- prometheus.MustNewConstMetric(progDesc, prometheus.GaugeValue, 1)
 
-	l := strings.SplitN(p.target, " ", 3)
+	ch <- prometheus.MustNewConstMetric(scrapeDurationDesc, prometheus.GaugeValue, time.Since(start).Seconds(), name)
+	ch <- prometheus.MustNewConstMetric(scrapeSuccessDesc, prometheus.GaugeValue, success, name)
+}
+
+// emitMetrics writes the ping_rtt_*/ping_loss_percent series for a single
+// target, keyed the same way mon.Monitor.Export keys its result map
+// ("host ip ip_version").
+func emitMetrics(ch chan<- prometheus.Metric, targetKey string, metrics *mon.Metrics) {
+	l := strings.SplitN(targetKey, " ", 3)
 
 	if metrics.PacketsSent > metrics.PacketsLost {
 		if enableDeprecatedMetrics {
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	mon "github.com/digineo/go-ping/monitor"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// TestCollectTargetEmitsMetrics checks that collectTarget emits metrics for a
+// target with usable samples; it checks that the output is not empty, not
+// the exact set of series.
+func TestCollectTargetEmitsMetrics(t *testing.T) {
+	metrics := &mon.Metrics{PacketsSent: 10, PacketsLost: 0}
+	ch := make(chan prometheus.Metric, 64)
+
+	collectTarget(ch, "example.com 192.0.2.1 4", metrics)
+	close(ch)
+
+	count := 0
+	for range ch {
+		count++
+	}
+
+	// Expected: bestDesc, worstDesc, meanDesc, stddevDesc, lossDesc, the
+	// deprecated rttDesc series (enableDeprecatedMetrics defaults to true)
+	// + duration + success.
+	if count == 0 {
+		t.Fatal("expected collectTarget to emit metrics")
+	}
+}
+
+// TestCollectTargetDoesNotBlock is a regression test for a bug where
+// emitMetrics' output was forwarded through a fixed-capacity buffer that was
+// only drained after emitMetrics returned: once emitMetrics produced more
+// items than that capacity (e.g. under --metrics.rttunit=both, which
+// roughly doubles the per-target count), the producer goroutine blocked
+// forever on the buffer and collectTarget never returned. collectTarget now
+// writes straight to ch and must always return promptly.
+func TestCollectTargetDoesNotBlock(t *testing.T) {
+	metrics := &mon.Metrics{PacketsSent: 10, PacketsLost: 0}
+	ch := make(chan prometheus.Metric, 64)
+
+	done := make(chan struct{})
+	go func() {
+		collectTarget(ch, "example.com 192.0.2.1 4", metrics)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("collectTarget did not return promptly")
+	}
+}
+
+// TestCollectTargetSuccessReflectsUsableSamples is a regression test for
+// scrape_collector_success being hardcoded to 1: it must mirror the
+// PacketsSent > PacketsLost gate emitMetrics uses, so a target with no
+// usable samples (e.g. 100% loss) is visible as a partial-scrape failure
+// instead of a gauge that can never be anything but 1.
+func TestCollectTargetSuccessReflectsUsableSamples(t *testing.T) {
+	cases := []struct {
+		name    string
+		metrics *mon.Metrics
+		want    float64
+	}{
+		{"has usable samples", &mon.Metrics{PacketsSent: 10, PacketsLost: 3}, 1},
+		{"all packets lost", &mon.Metrics{PacketsSent: 10, PacketsLost: 10}, 0},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ch := make(chan prometheus.Metric, 64)
+			collectTarget(ch, "example.com 192.0.2.1 4", tc.metrics)
+			close(ch)
+
+			var got float64 = -1
+			for m := range ch {
+				if m.Desc() != scrapeSuccessDesc {
+					continue
+				}
+				var pb dto.Metric
+				if err := m.Write(&pb); err != nil {
+					t.Fatalf("write metric: %v", err)
+				}
+				got = pb.GetGauge().GetValue()
+			}
+
+			if got != tc.want {
+				t.Errorf("scrape_collector_success = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+// Package events implements a dnstap-style structured event stream derived
+// from ping probe results. mon.Monitor only exposes windowed aggregates
+// (Export), not a per-echo callback, so a Stream emits one Event per target
+// per ping.interval tick rather than one per ICMP echo; operators still get
+// a near-real-time feed for offline analysis or alerting without waiting
+// for a scrape, but RTT and similar fields reflect the aggregate as of that
+// tick, not the single most recent probe.
+package events
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"time"
+)
+
+// Event describes a target's ping counters as of one poll of mon.Monitor.
+// RTT is the best round-trip time observed over the monitor's whole history
+// window as of Timestamp, not necessarily the probe that triggered this
+// Event - see the package doc comment. PacketsSent is the monitor's
+// cumulative echo-request count for the target, not an ICMP sequence
+// number - it only ever increases and resets if the target is re-added.
+// Timeout reports whether every echo since the previous Event for this
+// target was lost, not whether the target has never once answered.
+type Event struct {
+	Target      string
+	ResolvedIP  net.IP
+	PacketsSent int
+	RTT         time.Duration
+	Timeout     bool
+	Timestamp   time.Time
+}
+
+// Sink receives encoded events. Implementations must be safe to call from a
+// single goroutine only; the Stream never calls a Sink concurrently.
+type Sink interface {
+	Emit(e *Event) error
+	Close() error
+}
+
+// Stream fans out events to a Sink over a buffered channel, so a slow or
+// unreachable Sink cannot stall the monitor loop that produces events.
+type Stream struct {
+	sink    Sink
+	logger  *slog.Logger
+	events  chan *Event
+	dropped chan struct{}
+	done    chan struct{}
+}
+
+// NewStream starts a Stream delivering events to sink, logging with logger
+// any Emit failures that would otherwise go unnoticed (e.g. a SocketSink
+// stuck reconnecting). bufferSize controls how many events may queue before
+// new events are dropped instead of blocking the caller.
+func NewStream(sink Sink, bufferSize int, logger *slog.Logger) *Stream {
+	if bufferSize <= 0 {
+		bufferSize = 256
+	}
+
+	s := &Stream{
+		sink:    sink,
+		logger:  logger,
+		events:  make(chan *Event, bufferSize),
+		dropped: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	go s.run()
+
+	return s
+}
+
+// Send enqueues e for delivery. It never blocks: if the buffer is full, e is
+// dropped and the drop is flagged (see Dropped); how many were dropped is not
+// recorded.
+func (s *Stream) Send(e *Event) {
+	select {
+	case s.events <- e:
+	default:
+		select {
+		case s.dropped <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Dropped reports whether events have been dropped since the last call.
+func (s *Stream) Dropped() bool {
+	select {
+	case <-s.dropped:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close stops the delivery goroutine and closes the underlying sink.
+func (s *Stream) Close() error {
+	close(s.events)
+	<-s.done
+
+	return s.sink.Close()
+}
+
+func (s *Stream) run() {
+	defer close(s.done)
+
+	for e := range s.events {
+		if err := s.sink.Emit(e); err != nil {
+			if errors.Is(err, ErrSinkUnavailable) {
+				s.logger.Debug("events: sink unavailable, dropping event", "error", err)
+			} else {
+				s.logger.Warn("events: sink delivery failed", "error", err)
+			}
+		}
+	}
+}
+
+// ErrSinkUnavailable is returned by a Sink when it cannot currently deliver
+// an event but will retry on a later call (e.g. while reconnecting).
+var ErrSinkUnavailable = fmt.Errorf("events: sink unavailable")
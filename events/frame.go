@@ -0,0 +1,50 @@
+package events
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// maxFrameSize guards against a corrupt or malicious length prefix causing an
+// unbounded allocation when reading frames back.
+const maxFrameSize = 1 << 20
+
+// encodeFrame serializes e as a length-prefixed frame matching the
+// ProbeEvent message in events.proto: a 4-byte big-endian length followed by
+// the marshaled payload.
+func encodeFrame(e *Event) []byte {
+	payload := marshalProbeEvent(e)
+
+	frame := make([]byte, 4+len(payload))
+	binary.BigEndian.PutUint32(frame, uint32(len(payload)))
+	copy(frame[4:], payload)
+
+	return frame
+}
+
+// writeFrame writes e to w as a single length-prefixed frame.
+func writeFrame(w io.Writer, e *Event) error {
+	_, err := w.Write(encodeFrame(e))
+	return err
+}
+
+// readFrame reads a single length-prefixed frame from r.
+func readFrame(r io.Reader) ([]byte, error) {
+	var lenBuf [4]byte
+	if _, err := io.ReadFull(r, lenBuf[:]); err != nil {
+		return nil, err
+	}
+
+	n := binary.BigEndian.Uint32(lenBuf[:])
+	if n > maxFrameSize {
+		return nil, fmt.Errorf("events: frame size %d exceeds limit", n)
+	}
+
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
@@ -0,0 +1,65 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// jsonEvent is the JSON-lines representation of an Event, kept separate from
+// Event so the wire format can evolve independently of the in-process type.
+type jsonEvent struct {
+	Target      string  `json:"target"`
+	ResolvedIP  string  `json:"resolved_ip,omitempty"`
+	PacketsSent int     `json:"packets_sent"`
+	RTTSeconds  float64 `json:"rtt_seconds,omitempty"`
+	Timeout     bool    `json:"timeout"`
+	Timestamp   string  `json:"timestamp"`
+}
+
+// FileSink appends one JSON object per event to a file, for operators who
+// want to tail or rotate a plain log rather than run a socket consumer.
+type FileSink struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+// NewFileSink opens path for appending (creating it if necessary) and
+// returns a FileSink that writes to it.
+func NewFileSink(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("events: open %s: %w", path, err)
+	}
+
+	return &FileSink{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+// Emit appends e to the file as a single JSON line.
+func (s *FileSink) Emit(e *Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	je := jsonEvent{
+		Target:      e.Target,
+		PacketsSent: e.PacketsSent,
+		RTTSeconds:  e.RTT.Seconds(),
+		Timeout:     e.Timeout,
+		Timestamp:   e.Timestamp.Format("2006-01-02T15:04:05.000000Z07:00"),
+	}
+	if e.ResolvedIP != nil {
+		je.ResolvedIP = e.ResolvedIP.String()
+	}
+
+	return s.enc.Encode(&je)
+}
+
+// Close closes the underlying file.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.f.Close()
+}
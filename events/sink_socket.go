@@ -0,0 +1,78 @@
+package events
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// reconnectBackoff bounds a single dial attempt and, after a failed attempt,
+// is also the cooldown before the next one is allowed - so a downed peer
+// degrades Emit to a cheap no-op (returning ErrSinkUnavailable) between
+// attempts instead of blocking every call for up to reconnectBackoff.
+const reconnectBackoff = 2 * time.Second
+
+// SocketSink writes length-prefixed frames (see frame.go) to a Unix domain
+// socket, reconnecting when the peer goes away instead of failing every
+// subsequent Emit.
+type SocketSink struct {
+	path string
+
+	mu          sync.Mutex
+	conn        net.Conn
+	nextAttempt time.Time
+}
+
+// NewSocketSink dials path and returns a SocketSink. The initial connection
+// is best-effort: if it fails, Emit will keep trying to reconnect.
+func NewSocketSink(path string) *SocketSink {
+	s := &SocketSink{path: path}
+	s.conn, _ = net.Dial("unix", path)
+
+	return s
+}
+
+// Emit writes e to the socket, reconnecting first if the previous attempt
+// left the connection closed. While reconnecting, at most one dial is
+// attempted per reconnectBackoff interval; in between, Emit fails fast with
+// ErrSinkUnavailable so Stream.run() keeps draining its buffer instead of
+// stalling on a redial per event.
+func (s *SocketSink) Emit(e *Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		if time.Now().Before(s.nextAttempt) {
+			return ErrSinkUnavailable
+		}
+
+		conn, err := net.DialTimeout("unix", s.path, reconnectBackoff)
+		if err != nil {
+			s.nextAttempt = time.Now().Add(reconnectBackoff)
+			return fmt.Errorf("events: dial %s: %w", s.path, err)
+		}
+		s.conn = conn
+	}
+
+	if err := writeFrame(s.conn, e); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		s.nextAttempt = time.Now().Add(reconnectBackoff)
+		return fmt.Errorf("events: write to %s: %w", s.path, err)
+	}
+
+	return nil
+}
+
+// Close closes the underlying connection, if any.
+func (s *SocketSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+
+	return s.conn.Close()
+}
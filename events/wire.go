@@ -0,0 +1,51 @@
+package events
+
+import (
+	"encoding/binary"
+)
+
+// marshalProbeEvent encodes e using the standard protobuf wire format for
+// the ProbeEvent message defined in events.proto. It is hand-rolled rather
+// than generated by protoc so the events package has no build-time
+// dependency on protoc-gen-go; the field numbers and types must stay in
+// sync with events.proto.
+func marshalProbeEvent(e *Event) []byte {
+	buf := make([]byte, 0, 64)
+
+	buf = appendTagString(buf, 1, e.Target)
+	buf = appendTagBytes(buf, 2, e.ResolvedIP)
+	buf = appendTagVarint(buf, 3, uint64(e.PacketsSent))
+	buf = appendTagVarint(buf, 4, uint64(e.RTT.Microseconds()))
+	buf = appendTagBool(buf, 5, e.Timeout)
+	buf = appendTagVarint(buf, 7, uint64(e.Timestamp.UnixNano()))
+
+	return buf
+}
+
+func appendTagVarint(buf []byte, field int, v uint64) []byte {
+	buf = appendVarint(buf, uint64(field)<<3) // wire type 0: varint
+	return appendVarint(buf, v)
+}
+
+func appendTagBool(buf []byte, field int, v bool) []byte {
+	if v {
+		return appendTagVarint(buf, field, 1)
+	}
+	return appendTagVarint(buf, field, 0)
+}
+
+func appendTagBytes(buf []byte, field int, v []byte) []byte {
+	buf = appendVarint(buf, uint64(field)<<3|2) // wire type 2: length-delimited
+	buf = appendVarint(buf, uint64(len(v)))
+	return append(buf, v...)
+}
+
+func appendTagString(buf []byte, field int, v string) []byte {
+	return appendTagBytes(buf, field, []byte(v))
+}
+
+func appendVarint(buf []byte, v uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	return append(buf, tmp[:n]...)
+}
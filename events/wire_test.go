@@ -0,0 +1,122 @@
+package events
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+)
+
+// decodeProbeEvent parses a ProbeEvent payload back into the values
+// marshalProbeEvent wrote, far enough to assert the hand-rolled encoder
+// round-trips. It is test-only and does not aim to be a general protobuf
+// decoder.
+func decodeProbeEvent(t *testing.T, buf []byte) (target string, resolvedIP net.IP, packetsSent int, rttMicros int64, timeout bool, timestampUnixNano int64) {
+	t.Helper()
+
+	for len(buf) > 0 {
+		key, n := binary.Uvarint(buf)
+		if n <= 0 {
+			t.Fatalf("decodeProbeEvent: bad tag varint")
+		}
+		buf = buf[n:]
+
+		field := key >> 3
+		wireType := key & 0x7
+
+		switch wireType {
+		case 0: // varint
+			v, n := binary.Uvarint(buf)
+			if n <= 0 {
+				t.Fatalf("decodeProbeEvent: bad value varint for field %d", field)
+			}
+			buf = buf[n:]
+
+			switch field {
+			case 3:
+				packetsSent = int(v)
+			case 4:
+				rttMicros = int64(v)
+			case 5:
+				timeout = v != 0
+			case 7:
+				timestampUnixNano = int64(v)
+			default:
+				t.Fatalf("decodeProbeEvent: unexpected varint field %d", field)
+			}
+		case 2: // length-delimited
+			l, n := binary.Uvarint(buf)
+			if n <= 0 {
+				t.Fatalf("decodeProbeEvent: bad length varint for field %d", field)
+			}
+			buf = buf[n:]
+			v := buf[:l]
+			buf = buf[l:]
+
+			switch field {
+			case 1:
+				target = string(v)
+			case 2:
+				resolvedIP = net.IP(v)
+			default:
+				t.Fatalf("decodeProbeEvent: unexpected length-delimited field %d", field)
+			}
+		default:
+			t.Fatalf("decodeProbeEvent: unsupported wire type %d", wireType)
+		}
+	}
+
+	return
+}
+
+func TestMarshalProbeEventRoundtrip(t *testing.T) {
+	e := &Event{
+		Target:      "example.com",
+		ResolvedIP:  net.ParseIP("192.0.2.1"),
+		PacketsSent: 42,
+		RTT:         15 * time.Millisecond,
+		Timeout:     true,
+		Timestamp:   time.Unix(0, 1700000000000000000),
+	}
+
+	buf := marshalProbeEvent(e)
+
+	target, resolvedIP, packetsSent, rttMicros, timeout, ts := decodeProbeEvent(t, buf)
+	if target != e.Target {
+		t.Errorf("target = %q, want %q", target, e.Target)
+	}
+	if !resolvedIP.Equal(e.ResolvedIP) {
+		t.Errorf("resolvedIP = %v, want %v", resolvedIP, e.ResolvedIP)
+	}
+	if packetsSent != e.PacketsSent {
+		t.Errorf("packetsSent = %d, want %d", packetsSent, e.PacketsSent)
+	}
+	if rttMicros != e.RTT.Microseconds() {
+		t.Errorf("rttMicros = %d, want %d", rttMicros, e.RTT.Microseconds())
+	}
+	if timeout != e.Timeout {
+		t.Errorf("timeout = %v, want %v", timeout, e.Timeout)
+	}
+	if ts != e.Timestamp.UnixNano() {
+		t.Errorf("timestamp = %d, want %d", ts, e.Timestamp.UnixNano())
+	}
+}
+
+func TestEncodeFrameWriteReadRoundtrip(t *testing.T) {
+	e := &Event{Target: "example.com", PacketsSent: 1, Timestamp: time.Unix(0, 1700000000000000000)}
+
+	var buf bytes.Buffer
+	if err := writeFrame(&buf, e); err != nil {
+		t.Fatalf("writeFrame: %v", err)
+	}
+
+	payload, err := readFrame(&buf)
+	if err != nil {
+		t.Fatalf("readFrame: %v", err)
+	}
+
+	if !bytes.Equal(payload, marshalProbeEvent(e)) {
+		t.Errorf("readFrame payload does not match marshalProbeEvent output")
+	}
+}
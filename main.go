@@ -3,21 +3,24 @@ package main
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"net"
 	"net/http"
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/digineo/go-ping"
 	mon "github.com/digineo/go-ping/monitor"
 
 	"github.com/czerwonk/ping_exporter/config"
+	"github.com/czerwonk/ping_exporter/events"
+	"github.com/czerwonk/ping_exporter/probers"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
-	"github.com/prometheus/common/log"
 	"gopkg.in/alecthomas/kingpin.v2"
 )
 
@@ -34,9 +37,11 @@ var (
 	historySize    = kingpin.Flag("ping.history-size", "Number of results to remember per target").Default("10").Int()
 	dnsRefresh     = kingpin.Flag("dns.refresh", "Interval for refreshing DNS records and updating targets accordingly (0 if disabled)").Default("1m").Duration()
 	dnsNameServer  = kingpin.Flag("dns.nameserver", "DNS server used to resolve hostname of targets").Default("").String()
-	logLevel       = kingpin.Flag("log.level", "Only log messages with the given severity or above. Valid levels: [debug, info, warn, error, fatal]").Default("info").String()
+	logLevel       = kingpin.Flag("log.level", "Only log messages with the given severity or above. Valid levels: [debug, info, warn, error]").Default("info").String()
+	logFormat      = kingpin.Flag("log.format", "Output format of log messages. Valid formats: [logfmt, json]").Default("logfmt").String()
 	targets        = kingpin.Arg("targets", "A list of targets to ping").Strings()
 	targetsTimeout = kingpin.Flag("targets.timeout", "Timeout in seconds to remove not queried targets").Default("10").Int()
+	scrapeTimeout  = kingpin.Flag("scrape.timeout", "Timeout for a tcp_connect/http_get probe whose module sets no timeout; collecting ICMP metrics is not bounded by it").Default("10s").Duration()
 )
 
 var (
@@ -50,6 +55,15 @@ var (
 	resolver *net.Resolver
 
 	targetsMap sync.Map
+
+	eventStream *events.Stream
+
+	// activeMonitor holds the *mon.Monitor currently serving scrapes. It is
+	// swapped out by the config reloader when ping.interval/timeout/size
+	// change, so it is read afresh on every scrape rather than captured once.
+	activeMonitor atomic.Pointer[mon.Monitor]
+
+	reloader *configReloader
 )
 
 func init() {
@@ -62,8 +76,9 @@ func main() {
 		os.Exit(0)
 	}
 
-	if err := log.Logger.SetLevel(log.Base(), *logLevel); err != nil {
-		log.Errorln(err)
+	logger, err := newLogger(*logLevel, *logFormat)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 
@@ -79,10 +94,10 @@ func main() {
 	if rttMetricsScale = rttUnitFromString(*rttMode); rttMetricsScale == rttInvalid {
 		kingpin.FatalUsage("metrics.rttunit must be `ms` for millis, or `s` for seconds, or `both`")
 	}
-	log.Infof("rtt units: %#v", rttMetricsScale)
+	logger.Info("rtt units", "scale", rttMetricsScale)
 
 	if mpath := *metricsPath; mpath == "" {
-		log.Warnln("web.telemetry-path is empty, correcting to `/metrics`")
+		logger.Warn("web.telemetry-path is empty, correcting to `/metrics`")
 		mpath = "/metrics"
 		metricsPath = &mpath
 	} else if mpath[0] != '/' {
@@ -90,7 +105,6 @@ func main() {
 		metricsPath = &mpath
 	}
 
-	var err error
 	cfg, err = loadConfig()
 	if err != nil {
 		kingpin.FatalUsage("could not load config.path: %v", err)
@@ -104,13 +118,42 @@ func main() {
 		kingpin.FatalUsage("ping.size must be between 0 and 65500")
 	}
 
-	m, err := startMonitor(cfg)
+	_, registry, err := startMonitor(cfg, logger)
 	if err != nil {
-		log.Errorln(err)
+		logger.Error(err.Error())
 		os.Exit(2)
 	}
 
-	startServer(m)
+	if *configFile != "" {
+		reloader = newConfigReloader(*configFile, registry, logger)
+		go reloader.watch()
+	}
+
+	startServer(logger)
+}
+
+// newLogger builds the root *slog.Logger from the --log.level and
+// --log.format flags, mirroring the logfmt/json choice Prometheus itself
+// offers since it moved off go-kit/log.
+func newLogger(level, format string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("log.level must be one of [debug, info, warn, error]: %w", err)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	var handler slog.Handler
+	switch format {
+	case "logfmt", "":
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	default:
+		return nil, fmt.Errorf("log.format must be `logfmt` or `json`")
+	}
+
+	return slog.New(handler), nil
 }
 
 func printVersion() {
@@ -120,8 +163,10 @@ func printVersion() {
 	fmt.Println("Metric exporter for go-icmp")
 }
 
-func startMonitor(cfg *config.Config) (*mon.Monitor, error) {
-	resolver = setupResolver(cfg)
+// newPinger builds the *mon.Monitor for cfg's ping settings. It is also used
+// by the config reloader to recreate the monitor when ping.interval,
+// ping.timeout or ping.size change at runtime.
+func newPinger(cfg *config.Config) (*mon.Monitor, error) {
 	var bind4, bind6 string
 	if ln, err := net.Listen("tcp4", "127.0.0.1:0"); err == nil {
 		// ipv4 enabled
@@ -147,6 +192,25 @@ func startMonitor(cfg *config.Config) (*mon.Monitor, error) {
 		cfg.Ping.Timeout.Duration())
 	monitor.HistorySize = cfg.Ping.History
 
+	return monitor, nil
+}
+
+func startMonitor(cfg *config.Config, logger *slog.Logger) (*mon.Monitor, *targetRegistry, error) {
+	resolver = setupResolver(cfg)
+
+	monitor, err := newPinger(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	activeMonitor.Store(monitor)
+
+	if stream, err := setupEventStream(cfg.Events, logger); err != nil {
+		logger.Error(err.Error())
+	} else if stream != nil {
+		eventStream = stream
+		go streamEvents(eventStream, cfg.Ping.Interval.Duration(), logger)
+	}
+
 	targets := make([]*target, len(cfg.Targets))
 	for i, host := range cfg.Targets {
 		t := &target{
@@ -159,58 +223,198 @@ func startMonitor(cfg *config.Config) (*mon.Monitor, error) {
 
 		err := t.addOrUpdateMonitor(monitor)
 		if err != nil {
-			log.Errorln(err)
+			logger.Error(err.Error(), "target", host)
 		}
 	}
 
-	go startDNSAutoRefresh(cfg.DNS.Refresh.Duration(), targets, monitor)
+	registry := newTargetRegistry(targets)
 
-	return monitor, nil
+	go startDNSAutoRefresh(cfg.DNS.Refresh.Duration(), registry, logger)
+
+	return monitor, registry, nil
 }
 
-func startDNSAutoRefresh(interval time.Duration, targets []*target, monitor *mon.Monitor) {
+// startDNSAutoRefresh re-resolves every target in registry once per
+// interval. It reads registry and activeMonitor afresh on each tick rather
+// than capturing them once, so it stays in sync with a configReloader
+// sharing the same registry: targets it adds or removes, and a monitor it
+// swaps in after a ping.interval/timeout/size change, take effect on the
+// very next tick.
+func startDNSAutoRefresh(interval time.Duration, registry *targetRegistry, logger *slog.Logger) {
 	if interval <= 0 {
 		return
 	}
 
 	for range time.NewTicker(interval).C {
-		refreshDNS(targets, monitor)
+		refreshDNS(registry.snapshot(), activeMonitor.Load(), logger)
 	}
 }
 
-func refreshDNS(targets []*target, monitor *mon.Monitor) {
-	log.Infoln("refreshing DNS")
+func refreshDNS(targets []*target, monitor *mon.Monitor, logger *slog.Logger) {
+	logger.Info("refreshing DNS")
 	for _, t := range targets {
 		go func(ta *target) {
 			err := ta.addOrUpdateMonitor(monitor)
 			if err != nil {
-				log.Errorf("could refresh dns: %v", err)
+				logger.Error("could not refresh dns", "target", ta.host, "error", err)
 			}
 		}(t)
 	}
 }
 
-func startServer(monitor *mon.Monitor) {
-	log.Infof("Starting ping exporter (Version: %s)", version)
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+// setupEventStream builds the configured events.Sink(s) for the dnstap-style
+// probe event stream, if any are configured. It returns a nil Stream (and no
+// error) when cfg.Events has neither a socket nor a file sink set.
+func setupEventStream(cfg config.Events, logger *slog.Logger) (*events.Stream, error) {
+	var sink events.Sink
+
+	switch {
+	case cfg.SocketPath != "" && cfg.FilePath != "":
+		return nil, fmt.Errorf("events: socket and file sinks are mutually exclusive")
+	case cfg.SocketPath != "":
+		sink = events.NewSocketSink(cfg.SocketPath)
+	case cfg.FilePath != "":
+		fileSink, err := events.NewFileSink(cfg.FilePath)
+		if err != nil {
+			return nil, fmt.Errorf("events: %w", err)
+		}
+		sink = fileSink
+	default:
+		return nil, nil
+	}
+
+	return events.NewStream(sink, cfg.BufferSize, logger), nil
+}
+
+// streamEvents polls activeMonitor.Load().Export() once per ping interval
+// and emits a probe event for every target whose packet counters advanced
+// since the last poll. It reads activeMonitor afresh on each tick, like
+// startDNSAutoRefresh, rather than capturing the monitor running at startup
+// once: otherwise a configReloader swapping in a new monitor after a
+// ping.interval/timeout/size change (and stopping the old one) would leave
+// streamEvents ranging Export() on a stopped monitor forever.
+//
+// mon.Monitor exposes no per-echo callback, so RTT is the best round-trip
+// time over the whole history window as of that poll, not necessarily the
+// probe that just completed - see the events package doc comment. Timeout
+// is derived from the delta against the previous poll, not the cumulative
+// counters, so it reports this tick's losses rather than latching true
+// forever once a target has dropped every packet it has ever sent.
+func streamEvents(stream *events.Stream, interval time.Duration, logger *slog.Logger) {
+	type counters struct {
+		sent int
+		lost int
+	}
+	last := make(map[string]counters)
+	var lastMonitor *mon.Monitor
+
+	for range time.NewTicker(interval).C {
+		monitor := activeMonitor.Load()
+		if monitor != lastMonitor {
+			// A fresh monitor starts its cumulative counters back at zero, so
+			// the previous monitor's counts are not a meaningful baseline:
+			// drop them rather than stalling every event for this target
+			// until the new counters climb back past the old ones.
+			last = make(map[string]counters)
+			lastMonitor = monitor
+		}
+
+		for t, metrics := range monitor.Export() {
+			l := strings.SplitN(t, " ", 3)
+			if len(l) < 2 {
+				continue
+			}
+
+			prev := last[t]
+			if metrics.PacketsSent <= prev.sent {
+				continue
+			}
+			deltaSent := metrics.PacketsSent - prev.sent
+			deltaLost := metrics.PacketsLost - prev.lost
+			last[t] = counters{sent: metrics.PacketsSent, lost: metrics.PacketsLost}
+
+			stream.Send(&events.Event{
+				Target:      l[0],
+				ResolvedIP:  net.ParseIP(l[1]),
+				PacketsSent: metrics.PacketsSent,
+				RTT:         metrics.Best,
+				Timeout:     deltaLost > 0 && deltaLost == deltaSent,
+				Timestamp:   time.Now(),
+			})
+
+			if stream.Dropped() {
+				logger.Warn("events: stream buffer full, dropping events")
+			}
+		}
+	}
+}
+
+// httpRequestDuration/httpRequestsTotal/httpRequestsInFlight are built with
+// plain prometheus.New* rather than promauto, which would register them
+// into prometheus.DefaultRegisterer - a gatherer startServer never exposes.
+// They're registered onto the /metrics registry explicitly instead, see
+// startServer.
+var (
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "ping_exporter",
+		Name:      "http_request_duration_seconds",
+		Help:      "Histogram of latencies for HTTP requests",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"handler", "method"})
+
+	httpRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "ping_exporter",
+		Name:      "http_requests_total",
+		Help:      "Count of HTTP requests by handler, method and status code",
+	}, []string{"code", "method", "handler"})
+
+	httpRequestsInFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "ping_exporter",
+		Name:      "http_requests_in_flight",
+		Help:      "Number of HTTP requests currently being served",
+	}, []string{"handler"})
+)
+
+// instrumentHandler wraps next with the standard promhttp duration/counter/
+// in-flight middleware, labeled with handlerName.
+func instrumentHandler(handlerName string, next http.HandlerFunc) http.HandlerFunc {
+	inFlight := httpRequestsInFlight.WithLabelValues(handlerName)
+	duration := httpRequestDuration.MustCurryWith(prometheus.Labels{"handler": handlerName})
+	counter := httpRequestsTotal.MustCurryWith(prometheus.Labels{"handler": handlerName})
+
+	return promhttp.InstrumentHandlerInFlight(inFlight,
+		promhttp.InstrumentHandlerDuration(duration,
+			promhttp.InstrumentHandlerCounter(counter, next))).ServeHTTP
+}
+
+func startServer(logger *slog.Logger) {
+	logger.Info("starting ping exporter", "version", version)
+	http.HandleFunc("/", instrumentHandler("/", func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprintf(w, indexHTML, *metricsPath)
-	})
+	}))
 	reg := prometheus.NewRegistry()
-	reg.MustRegister(&pingBatchCollector{monitor: monitor})
+	reg.MustRegister(&deviceCollector{logger: logger})
+	reg.MustRegister(httpRequestDuration, httpRequestsTotal, httpRequestsInFlight)
 	h := promhttp.HandlerFor(reg, promhttp.HandlerOpts{
-		ErrorLog:      log.NewErrorLogger(),
+		ErrorLog:      slog.NewLogLogger(logger.Handler(), slog.LevelError),
 		ErrorHandling: promhttp.ContinueOnError,
 	})
-	http.HandleFunc(*metricsPath, func(w http.ResponseWriter, r *http.Request) {
+	http.HandleFunc(*metricsPath, instrumentHandler(*metricsPath, func(w http.ResponseWriter, r *http.Request) {
 		tg := r.URL.Query().Get("target")
 		if tg == "" {
 			h.ServeHTTP(w, r)
 			return
 		}
+
+		if module := r.URL.Query().Get("module"); module != "" && module != defaultModule {
+			probeModule(w, r, module, tg, logger)
+			return
+		}
+
 		t := &target{
-			host:      tg,
-			delay:     time.Millisecond,
-			resolver:  resolver,
+			host:     tg,
+			delay:    time.Millisecond,
+			resolver: resolver,
 		}
 		addrs, err := t.resolver.LookupIPAddr(context.Background(), t.host)
 		if err != nil {
@@ -223,32 +427,60 @@ func startServer(monitor *mon.Monitor) {
 		}
 		to, ok := targetsMap.Load(tg)
 		if !ok {
-			log.Infof("Adding target: %s", tg)
-			if err := t.addIfNew(addrs[0], monitor); err != nil {
-				log.Errorf("failed to add target %s: %v", tg, err)
+			logger.Info("adding target", "target", tg)
+			if err := t.addIfNew(addrs[0], activeMonitor.Load()); err != nil {
+				logger.Error("failed to add target", "target", tg, "error", err)
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
 			}
 		} else {
 			to.(*time.Timer).Stop()
 		}
+		targetKey := t.nameForIP(addrs[0])
 		registry := prometheus.NewRegistry()
-		c := &pingCollector{target: t.nameForIP(addrs[0]), monitor: monitor}
+		c := &deviceCollector{logger: logger, targets: []string{targetKey}}
 		registry.MustRegister(c)
+
+		// Mirrors the PacketsSent > PacketsLost check emitMetrics uses to
+		// gate RTT emission, so probe_success actually reflects reachability
+		// instead of always reporting 1. A target with no samples yet (just
+		// added) reports unsuccessful until its first round of pings
+		// completes. Sharing registerProbeResult with the tcp_connect/
+		// http_get path keeps probe_success/probe_duration_seconds uniform
+		// between ?module= probes and ICMP ?target= probes - duration
+		// here is the ping RTT itself (metrics.Best), not how long this
+		// handler took, since the actual probing already happened in the
+		// background rather than synchronously within this request.
+		var (
+			result   probers.Result
+			duration time.Duration
+		)
+		if metrics, ok := activeMonitor.Load().Export()[targetKey]; ok && metrics.PacketsSent > metrics.PacketsLost {
+			result = probers.Result{Success: true}
+			duration = metrics.Best
+		}
+		registerProbeResult(registry, result, duration)
 		h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{
-			ErrorLog:      log.NewErrorLogger(),
+			ErrorLog:      slog.NewLogLogger(logger.Handler(), slog.LevelError),
 			ErrorHandling: promhttp.ContinueOnError,
 		})
 		targetsMap.Store(tg, time.AfterFunc(time.Duration(*targetsTimeout)*time.Second, func() {
-			log.Infof("Removing timed out target: %s", tg)
+			logger.Info("removing timed out target", "target", tg)
 			targetsMap.Delete(tg)
-			t.cleanUp(t.addresses, monitor)
+			t.cleanUp(t.addresses, activeMonitor.Load())
 		}))
 		h.ServeHTTP(w, r)
-	})
+	}))
 
-	log.Infof("Listening for %s on %s", *metricsPath, *listenAddress)
-	log.Fatal(http.ListenAndServe(*listenAddress, nil))
+	if reloader != nil {
+		http.HandleFunc("/-/reload", reloader.handleHTTP)
+	}
+
+	logger.Info("listening", "path", *metricsPath, "address", *listenAddress)
+	if err := http.ListenAndServe(*listenAddress, nil); err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
 }
 
 func loadConfig() (*config.Config, error) {
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/czerwonk/ping_exporter/config"
+	"github.com/czerwonk/ping_exporter/probers"
+)
+
+// defaultModule is used when ?module= is absent, keeping ICMP (served by
+// go-ping/monitor) as the default probe type.
+const defaultModule = "icmp"
+
+// probeModule runs a single synchronous tcp_connect/http_get probe against
+// target using the module profile from cfg.Modules, and writes the result
+// as probe_success/probe_duration_seconds plus module-specific gauges,
+// following blackbox-exporter's conventions.
+func probeModule(w http.ResponseWriter, r *http.Request, module, target string, logger *slog.Logger) {
+	mutex.Lock()
+	modCfg, ok := cfg.Modules[module]
+	mutex.Unlock()
+	if !ok {
+		http.Error(w, "unknown module: "+module, http.StatusBadRequest)
+		return
+	}
+
+	prober, err := probers.New(module, probers.Config{
+		TLS:                 convertTLSConfig(modCfg.TLSConfig),
+		ExpectedStatusCodes: modCfg.ExpectedStatusCodes,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	timeout := modCfg.Timeout.Duration()
+	if timeout <= 0 {
+		timeout = *scrapeTimeout
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	start := time.Now()
+	result, err := prober.Probe(ctx, target)
+	if err != nil {
+		logger.Info("probe failed", "module", module, "target", target, "error", err)
+	}
+
+	registry := prometheus.NewRegistry()
+	registerProbeResult(registry, result, time.Since(start))
+
+	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{
+		ErrorLog:      slog.NewLogLogger(logger.Handler(), slog.LevelError),
+		ErrorHandling: promhttp.ContinueOnError,
+	})
+	h.ServeHTTP(w, r)
+}
+
+// registerProbeResult registers probe_success, probe_duration_seconds and
+// one probe_<name> gauge per entry in result.Extra.
+func registerProbeResult(registry *prometheus.Registry, result probers.Result, duration time.Duration) {
+	success := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "probe_success",
+		Help: "Displays whether or not the probe was a success",
+	})
+	if result.Success {
+		success.Set(1)
+	}
+
+	probeDuration := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "probe_duration_seconds",
+		Help: "Returns how long the probe took to complete in seconds",
+	})
+	probeDuration.Set(duration.Seconds())
+
+	registry.MustRegister(success, probeDuration)
+
+	for name, value := range result.Extra {
+		g := prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_" + name,
+			Help: "Additional measurement reported by the probe module",
+		})
+		g.Set(value)
+		registry.MustRegister(g)
+	}
+}
+
+// convertTLSConfig adapts config.TLSConfig (as parsed from the `modules:`
+// section) to probers.TLSConfig, leaving it nil when unset.
+func convertTLSConfig(c *config.TLSConfig) *probers.TLSConfig {
+	if c == nil {
+		return nil
+	}
+
+	return &probers.TLSConfig{
+		InsecureSkipVerify: c.InsecureSkipVerify,
+		ServerName:         c.ServerName,
+	}
+}
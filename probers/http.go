@@ -0,0 +1,90 @@
+package probers
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"time"
+)
+
+// HTTPProber implements the http_get module: it fetches target (a full
+// URL) and records connect time, TLS handshake time, time to first byte
+// and the response status code.
+type HTTPProber struct {
+	TLS                 *TLSConfig
+	ExpectedStatusCodes []int
+}
+
+func (p *HTTPProber) Probe(ctx context.Context, target string) (Result, error) {
+	extra := make(map[string]float64)
+	start := time.Now()
+	var connectStart, tlsStart time.Time
+
+	trace := &httptrace.ClientTrace{
+		ConnectStart: func(string, string) { connectStart = time.Now() },
+		ConnectDone: func(string, string, error) {
+			if !connectStart.IsZero() {
+				extra["tcp_connect_duration_seconds"] = time.Since(connectStart).Seconds()
+			}
+		},
+		TLSHandshakeStart: func() { tlsStart = time.Now() },
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			if !tlsStart.IsZero() {
+				extra["tls_handshake_duration_seconds"] = time.Since(tlsStart).Seconds()
+			}
+		},
+		GotFirstResponseByte: func() {
+			extra["time_to_first_byte_seconds"] = time.Since(start).Seconds()
+		},
+	}
+
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), http.MethodGet, target, nil)
+	if err != nil {
+		return Result{Success: false}, err
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: p.tlsConfig(),
+		},
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return Result{Success: false, Extra: extra}, err
+	}
+	defer resp.Body.Close()
+
+	extra["status_code"] = float64(resp.StatusCode)
+
+	return Result{
+		Success: p.statusExpected(resp.StatusCode),
+		Extra:   extra,
+	}, nil
+}
+
+func (p *HTTPProber) tlsConfig() *tls.Config {
+	if p.TLS == nil {
+		return nil
+	}
+
+	return &tls.Config{
+		InsecureSkipVerify: p.TLS.InsecureSkipVerify,
+		ServerName:         p.TLS.ServerName,
+	}
+}
+
+func (p *HTTPProber) statusExpected(code int) bool {
+	if len(p.ExpectedStatusCodes) == 0 {
+		return code >= 200 && code < 300
+	}
+
+	for _, c := range p.ExpectedStatusCodes {
+		if c == code {
+			return true
+		}
+	}
+
+	return false
+}
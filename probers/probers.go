@@ -0,0 +1,53 @@
+// Package probers implements the blackbox-exporter-style probe modules
+// (tcp_connect, http_get, ...) selectable via the ?module= query parameter,
+// as an alternative to the ICMP probing go-ping/monitor does in the
+// background. Unlike ICMP, these probes run synchronously for the duration
+// of the scrape that requested them.
+package probers
+
+import (
+	"context"
+	"fmt"
+)
+
+// Result is what a Prober reports for a single probe attempt. The overall
+// probe duration is measured by the caller (see registerProbeResult), not
+// carried on Result. Extra holds module-specific measurements (e.g.
+// "tls_handshake_duration_seconds", "status_code") exposed as additional gauges by
+// the caller.
+type Result struct {
+	Success bool
+	Extra   map[string]float64
+}
+
+// Prober probes a single target and reports the outcome. Implementations
+// must return promptly once ctx is done.
+type Prober interface {
+	Probe(ctx context.Context, target string) (Result, error)
+}
+
+// New returns the Prober for module, configured from cfg.
+func New(module string, cfg Config) (Prober, error) {
+	switch module {
+	case "tcp_connect":
+		return &TCPProber{TLS: cfg.TLS}, nil
+	case "http_get":
+		return &HTTPProber{TLS: cfg.TLS, ExpectedStatusCodes: cfg.ExpectedStatusCodes}, nil
+	default:
+		return nil, fmt.Errorf("probers: unknown module %q", module)
+	}
+}
+
+// Config mirrors the TLS settings and accepted status codes of a single
+// entry in config.Config's `modules:` map. The per-module timeout governs
+// the context passed to Probe and so isn't part of Config itself.
+type Config struct {
+	TLS                 *TLSConfig
+	ExpectedStatusCodes []int
+}
+
+// TLSConfig is the subset of tls.Config operators can tune per module.
+type TLSConfig struct {
+	InsecureSkipVerify bool
+	ServerName         string
+}
@@ -0,0 +1,43 @@
+package probers
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"time"
+)
+
+// TCPProber implements the tcp_connect module: it times a plain TCP
+// connect, and additionally a TLS handshake when target is dialed over
+// tls (signalled by TLS being non-nil).
+type TCPProber struct {
+	TLS *TLSConfig
+}
+
+func (p *TCPProber) Probe(ctx context.Context, target string) (Result, error) {
+	start := time.Now()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", target)
+	if err != nil {
+		return Result{Success: false}, err
+	}
+	defer conn.Close()
+
+	connectDuration := time.Since(start)
+	extra := map[string]float64{"tcp_connect_duration_seconds": connectDuration.Seconds()}
+
+	if p.TLS != nil {
+		tlsStart := time.Now()
+		tlsConn := tls.Client(conn, &tls.Config{
+			InsecureSkipVerify: p.TLS.InsecureSkipVerify,
+			ServerName:         p.TLS.ServerName,
+		})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return Result{Success: false, Extra: extra}, err
+		}
+		extra["tls_handshake_duration_seconds"] = time.Since(tlsStart).Seconds()
+	}
+
+	return Result{Success: true, Extra: extra}, nil
+}
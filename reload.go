@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+
+	mon "github.com/digineo/go-ping/monitor"
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/czerwonk/ping_exporter/config"
+)
+
+// targetRegistry holds the set of targets currently being monitored. It is
+// shared between the DNS auto-refresh loop (main.go) and, when config.path
+// is set, the configReloader below, so both operate on the live target set
+// instead of a snapshot taken once at startup: a reload that adds, removes
+// or recreates targets is immediately visible to the next DNS refresh tick,
+// and the refresh loop never resurrects a target the reloader just removed.
+type targetRegistry struct {
+	mu      sync.Mutex
+	targets []*target
+}
+
+// newTargetRegistry wraps the targets built during startup.
+func newTargetRegistry(targets []*target) *targetRegistry {
+	return &targetRegistry{targets: targets}
+}
+
+// snapshot returns a copy of the currently monitored targets.
+func (r *targetRegistry) snapshot() []*target {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make([]*target, len(r.targets))
+	copy(out, r.targets)
+	return out
+}
+
+// set replaces the currently monitored targets.
+func (r *targetRegistry) set(targets []*target) {
+	r.mu.Lock()
+	r.targets = targets
+	r.mu.Unlock()
+}
+
+// configReloader re-parses the config file and reconciles the monitored
+// targets on SIGHUP, on a filesystem change to the file, or on a POST to
+// /-/reload, without restarting the process.
+type configReloader struct {
+	path     string
+	logger   *slog.Logger
+	registry *targetRegistry
+
+	mu sync.Mutex
+}
+
+// newConfigReloader creates a configReloader for the registry already
+// populated by startMonitor.
+func newConfigReloader(path string, registry *targetRegistry, logger *slog.Logger) *configReloader {
+	return &configReloader{path: path, registry: registry, logger: logger}
+}
+
+// watch blocks handling SIGHUP and fsnotify events until the process exits.
+// It is meant to be run in its own goroutine.
+func (r *configReloader) watch() {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		r.logger.Error("could not create config watcher", "error", err)
+		return
+	}
+	defer watcher.Close()
+
+	// Watch the containing directory rather than r.path itself: tools that
+	// replace the config atomically (editors saving via rename, Kubernetes
+	// swapping a ConfigMap symlink) remove the original inode, after which a
+	// watch on the file directly goes silently dead and never fires again.
+	dir := filepath.Dir(r.path)
+	if err := watcher.Add(dir); err != nil {
+		r.logger.Error("could not watch config directory", "path", dir, "error", err)
+		return
+	}
+
+	for {
+		select {
+		case <-sighup:
+			r.reload("sighup")
+		case ev, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(ev.Name) != filepath.Clean(r.path) {
+				continue
+			}
+			if ev.Op&(fsnotify.Write|fsnotify.Create) != 0 {
+				r.reload("fsnotify")
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			r.logger.Error("config watcher error", "error", err)
+		}
+	}
+}
+
+// handleHTTP implements the POST /-/reload endpoint.
+func (r *configReloader) handleHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.reload("http"); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// reload re-parses the config file and reconciles targets, recreating the
+// monitor first if ping.interval, ping.timeout or ping.size changed.
+func (r *configReloader) reload(trigger string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, err := os.Open(r.path)
+	if err != nil {
+		configReloadsTotal.WithLabelValues("failure").Inc()
+		return fmt.Errorf("reload config: %w", err)
+	}
+	newCfg, err := config.FromYAML(f)
+	f.Close()
+	if err != nil {
+		configReloadsTotal.WithLabelValues("failure").Inc()
+		return fmt.Errorf("reload config: %w", err)
+	}
+	addFlagToConfig(newCfg)
+
+	mutex.Lock()
+	monitor := activeMonitor.Load()
+	var oldMonitor *mon.Monitor
+	if pingSettingsChanged(cfg, newCfg) {
+		m, err := newPinger(newCfg)
+		if err != nil {
+			mutex.Unlock()
+			configReloadsTotal.WithLabelValues("failure").Inc()
+			return fmt.Errorf("reload config: %w", err)
+		}
+		oldMonitor = monitor
+		monitor = m
+		activeMonitor.Store(monitor)
+		r.registry.set(nil)
+	}
+	cfg = newCfg
+	mutex.Unlock()
+
+	// Stop the replaced monitor only after activeMonitor is swapped and the
+	// lock is released, otherwise its raw socket and per-target goroutines
+	// would leak on every reload that changes ping.interval/timeout/size.
+	if oldMonitor != nil {
+		oldMonitor.Stop()
+	}
+
+	updated := reconcileTargets(r.registry.snapshot(), newCfg, monitor, r.logger)
+	r.registry.set(updated)
+
+	configReloadsTotal.WithLabelValues("success").Inc()
+	r.logger.Info("config reloaded", "trigger", trigger, "targets", len(updated))
+
+	return nil
+}
+
+// pingSettingsChanged reports whether the ping interval, timeout or payload
+// size differ between old and new, which requires recreating the monitor.
+func pingSettingsChanged(old, updated *config.Config) bool {
+	return old.Ping.Interval != updated.Ping.Interval ||
+		old.Ping.Timeout != updated.Ping.Timeout ||
+		old.Ping.Size != updated.Ping.Size
+}
+
+// reconcileTargets adds targets present in cfg.Targets but missing from
+// current, and removes targets present in current but no longer in
+// cfg.Targets, returning the updated set.
+func reconcileTargets(current []*target, cfg *config.Config, monitor *mon.Monitor, logger *slog.Logger) []*target {
+	wanted := make(map[string]bool, len(cfg.Targets))
+	for _, host := range cfg.Targets {
+		wanted[host] = true
+	}
+
+	kept := make([]*target, 0, len(current))
+	for _, t := range current {
+		if wanted[t.host] {
+			kept = append(kept, t)
+			delete(wanted, t.host)
+			continue
+		}
+
+		if err := t.cleanUp(t.addresses, monitor); err != nil {
+			logger.Error("could not remove target", "target", t.host, "error", err)
+		}
+	}
+
+	for host := range wanted {
+		t := &target{
+			host:      host,
+			addresses: make([]net.IPAddr, 0),
+			resolver:  resolver,
+		}
+		if err := t.addOrUpdateMonitor(monitor); err != nil {
+			logger.Error("could not add target", "target", host, "error", err)
+			continue
+		}
+		kept = append(kept, t)
+	}
+
+	return kept
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/czerwonk/ping_exporter/config"
+)
+
+func TestPingSettingsChanged(t *testing.T) {
+	base := &config.Config{}
+	base.Ping.Interval.Set(5)
+	base.Ping.Timeout.Set(4)
+	base.Ping.Size = 56
+
+	cases := []struct {
+		name    string
+		mutate  func(*config.Config)
+		changed bool
+	}{
+		{"identical", func(*config.Config) {}, false},
+		{"interval changed", func(c *config.Config) { c.Ping.Interval.Set(10) }, true},
+		{"timeout changed", func(c *config.Config) { c.Ping.Timeout.Set(8) }, true},
+		{"size changed", func(c *config.Config) { c.Ping.Size = 128 }, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			updated := *base
+			tc.mutate(&updated)
+
+			if got := pingSettingsChanged(base, &updated); got != tc.changed {
+				t.Errorf("pingSettingsChanged() = %v, want %v", got, tc.changed)
+			}
+		})
+	}
+}